@@ -0,0 +1,351 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"html/template"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/text"
+	"gopkg.in/yaml.v3"
+)
+
+// isBatchInput reports whether input names a directory or a glob pattern,
+// in which case md2html switches into batch mode instead of converting a
+// single file.
+func isBatchInput(input string) bool {
+	if strings.ContainsAny(input, "*?[") {
+		return true
+	}
+	info, err := os.Stat(input)
+	return err == nil && info.IsDir()
+}
+
+// frontMatter is the set of fields md2html understands in a file's
+// front matter, whether it's written as YAML (---) or TOML (+++).
+type frontMatter struct {
+	Title  string   `yaml:"title" toml:"title"`
+	Date   string   `yaml:"date" toml:"date"`
+	Tags   []string `yaml:"tags" toml:"tags"`
+	Layout string   `yaml:"layout" toml:"layout"`
+}
+
+var dateLayouts = []string{time.RFC3339, "2006-01-02", "2006-01-02 15:04:05"}
+
+func parseFrontMatterDate(s string) time.Time {
+	for _, layout := range dateLayouts {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t
+		}
+	}
+	return time.Time{}
+}
+
+// splitFrontMatter strips a leading `---`/`+++` delimited front matter
+// block from input, returning the raw block, its format, and the
+// remaining Markdown body. It returns a nil block when input has none.
+func splitFrontMatter(input []byte) (raw []byte, format string, body []byte) {
+	switch {
+	case bytes.HasPrefix(input, []byte("---\n")):
+		if end := bytes.Index(input[4:], []byte("\n---\n")); end >= 0 {
+			return input[4 : 4+end], "yaml", input[4+end+5:]
+		}
+	case bytes.HasPrefix(input, []byte("+++\n")):
+		if end := bytes.Index(input[4:], []byte("\n+++\n")); end >= 0 {
+			return input[4 : 4+end], "toml", input[4+end+5:]
+		}
+	}
+	return nil, "", input
+}
+
+// parseFrontMatter splits and decodes the front matter of input, returning
+// it alongside the remaining Markdown body.
+func parseFrontMatter(input []byte) (frontMatter, []byte, error) {
+	raw, format, body := splitFrontMatter(input)
+	var fm frontMatter
+	switch format {
+	case "yaml":
+		if err := yaml.Unmarshal(raw, &fm); err != nil {
+			return fm, body, fmt.Errorf("parsing YAML front matter: %w", err)
+		}
+	case "toml":
+		if _, err := toml.Decode(string(raw), &fm); err != nil {
+			return fm, body, fmt.Errorf("parsing TOML front matter: %w", err)
+		}
+	}
+	return fm, body, nil
+}
+
+// articleMeta describes one converted article, enough to list it in an
+// auto-generated index page.
+type articleMeta struct {
+	Title   string
+	Date    time.Time
+	Tags    []string
+	OutPath string
+}
+
+// batchInputs resolves a directory or glob argument to the list of
+// Markdown files it covers. Directories are walked recursively and
+// relative output paths mirror the source tree; globs are flattened,
+// with output paths based on each match's base name.
+func batchInputs(input string) (files []string, relPath func(string) string, err error) {
+	if info, statErr := os.Stat(input); statErr == nil && info.IsDir() {
+		err = filepath.Walk(input, func(path string, info os.FileInfo, err error) error {
+			if err != nil {
+				return err
+			}
+			if !info.IsDir() && strings.EqualFold(filepath.Ext(path), ".md") {
+				files = append(files, path)
+			}
+			return nil
+		})
+		relPath = func(path string) string {
+			rel, err := filepath.Rel(input, path)
+			if err != nil {
+				return filepath.Base(path)
+			}
+			return rel
+		}
+		return files, relPath, err
+	}
+	files, err = filepath.Glob(input)
+	relPath = func(path string) string { return filepath.Base(path) }
+	return files, relPath, err
+}
+
+// batchOptions carries the single-file conversion flags (-format, -toc,
+// -toconly, -toc-depth, -math) through to batch mode, so that directory and
+// glob conversions behave the same way a single file would with the same
+// flags rather than silently ignoring them.
+type batchOptions struct {
+	format      string
+	toc         bool
+	tocOnly     bool
+	tocDepth    int
+	mathScripts template.HTML
+}
+
+// batchOutputExt returns the file extension for a converted article in the
+// given -format, mirroring the extension newRenderer's format would produce
+// for a single file.
+func batchOutputExt(format string) string {
+	switch format {
+	case "latex":
+		return ".tex"
+	case "json":
+		return ".json"
+	default:
+		return ".html"
+	}
+}
+
+// runBatch converts every Markdown file matched by input (a directory or
+// glob) under outputDir using the given opts and, if emitIndex is set,
+// writes an index.html that lists the results sorted by date, newest
+// first. Article pages are wrapped in the page template only when
+// opts.format is "html"; other formats are written out as-is, since the
+// built-in and template-dir page templates are HTML markup.
+func runBatch(markdown goldmark.Markdown, input, outputDir, templateDir string, opts batchOptions, emitIndex bool) error {
+	if outputDir == "" {
+		return fmt.Errorf("-out is required when the input is a directory or glob")
+	}
+	tmpls, err := loadBatchTemplates(templateDir)
+	if err != nil {
+		return err
+	}
+	renderer, err := newRenderer(opts.format, markdown)
+	if err != nil {
+		return err
+	}
+	outExt := batchOutputExt(opts.format)
+
+	files, relPath, err := batchInputs(input)
+	if err != nil {
+		return err
+	}
+
+	var articles []articleMeta
+	for _, path := range files {
+		raw, err := ioutil.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		fm, body, err := parseFrontMatter(raw)
+		if err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+
+		title := fm.Title
+		if title == "" {
+			title = getTitle(body)
+		}
+		date := parseFrontMatterDate(fm.Date)
+
+		doc := markdown.Parser().Parse(text.NewReader(body))
+
+		var tocHTML string
+		if opts.toc && opts.format == "html" {
+			tocHTML = renderTOC(collectTOC(doc, body, opts.tocDepth))
+		}
+
+		var rendered bytes.Buffer
+		if err := renderer.Render(&rendered, body, doc); err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+
+		content := rendered.String()
+		if opts.format == "html" {
+			if opts.tocOnly {
+				content = tocHTML
+			} else if opts.toc {
+				content = strings.Replace(content, "<p>[TOC]</p>\n", tocHTML, 1)
+			}
+		}
+
+		rel := relPath(path)
+		outRel := strings.TrimSuffix(rel, filepath.Ext(rel)) + outExt
+		outFile := filepath.Join(outputDir, outRel)
+		if err := os.MkdirAll(filepath.Dir(outFile), 0o755); err != nil {
+			return err
+		}
+
+		if opts.format == "html" {
+			body := template.HTML(`<article class="markdown-body">` + "\n" + content + "\n</article>")
+			if err := writeBatchPage(tmpls, outFile, fm.Layout, batchPageData{
+				Title:        title,
+				DefaultStyle: template.CSS(defaultStyle),
+				MathScripts:  opts.mathScripts,
+				Date:         date,
+				Tags:         fm.Tags,
+				Content:      template.HTML(content),
+				Body:         body,
+			}); err != nil {
+				return err
+			}
+		} else if err := ioutil.WriteFile(outFile, []byte(content), 0o644); err != nil {
+			return err
+		}
+
+		articles = append(articles, articleMeta{Title: title, Date: date, Tags: fm.Tags, OutPath: outRel})
+	}
+
+	if emitIndex {
+		sort.Slice(articles, func(i, j int) bool { return articles[i].Date.After(articles[j].Date) })
+		out, err := os.Create(filepath.Join(outputDir, "index.html"))
+		if err != nil {
+			return err
+		}
+		defer out.Close()
+		return tmpls.ExecuteTemplate(out, "index", batchIndexData{
+			Title:        "Index",
+			DefaultStyle: template.CSS(defaultStyle),
+			Articles:     articles,
+			Body:         renderIndexBody(articles),
+		})
+	}
+	return nil
+}
+
+// renderIndexBody builds the <ul> of article links shown on the generated
+// index page. It's computed directly rather than inlined in the template
+// so that the built-in "index" template can share the same "base" page
+// shell as "article" (see defaultBatchTemplates).
+func renderIndexBody(articles []articleMeta) template.HTML {
+	var buf bytes.Buffer
+	buf.WriteString(`<ul class="article-index">` + "\n")
+	for _, a := range articles {
+		fmt.Fprintf(&buf, `<li><a href="%s">%s</a>`, template.HTMLEscapeString(a.OutPath), template.HTMLEscapeString(a.Title))
+		if !a.Date.IsZero() {
+			fmt.Fprintf(&buf, ` <time>%s</time>`, a.Date.Format("2006-01-02"))
+		}
+		buf.WriteString("</li>\n")
+	}
+	buf.WriteString("</ul>\n")
+	return template.HTML(buf.String())
+}
+
+// batchPageData is the data made available to article.tmpl (and, via
+// "base", the built-in default). Body holds the full wrapped HTML body
+// (Content already wrapped in <article>); custom article.tmpl templates
+// may use either Content or Body depending on whether they want to
+// provide their own wrapper markup.
+type batchPageData struct {
+	Title        string
+	DefaultStyle template.CSS
+	MathScripts  template.HTML
+	Date         time.Time
+	Tags         []string
+	Content      template.HTML
+	Body         template.HTML
+}
+
+// batchIndexData is the data made available to index.tmpl (and, via
+// "base", the built-in default).
+type batchIndexData struct {
+	Title        string
+	DefaultStyle template.CSS
+	Articles     []articleMeta
+	Body         template.HTML
+}
+
+func writeBatchPage(tmpls *template.Template, outFile, layout string, data batchPageData) error {
+	out, err := os.Create(outFile)
+	if err != nil {
+		return err
+	}
+	defer out.Close()
+
+	name := "article"
+	if layout != "" {
+		name = layout
+	}
+	return tmpls.ExecuteTemplate(out, name, data)
+}
+
+// loadBatchTemplates loads the base/article/index templates from
+// templateDir (expecting base.tmpl, article.tmpl and index.tmpl) or,
+// when templateDir is empty, falls back to the built-in defaults. Pages
+// are executed as the templates named "article" (or the page's front-matter
+// layout) and "index", so the files must {{define}} those names; naming a
+// file article.tmpl or index.tmpl alone does not.
+func loadBatchTemplates(templateDir string) (*template.Template, error) {
+	if templateDir == "" {
+		return template.New("base").Parse(defaultBatchTemplates)
+	}
+	return template.ParseFiles(
+		filepath.Join(templateDir, "base.tmpl"),
+		filepath.Join(templateDir, "article.tmpl"),
+		filepath.Join(templateDir, "index.tmpl"),
+	)
+}
+
+const defaultBatchTemplates = `
+{{define "base"}}<!DOCTYPE html>
+<html lang="en">
+  <head>
+    <meta charset="utf-8">
+	<title>{{.Title}}</title>
+	<style>
+	   {{ .DefaultStyle }}
+	</style>
+
+	{{.MathScripts}}
+
+	</head>
+	<body>
+	{{.Body}}
+	</body></html>
+{{end}}
+
+{{define "article"}}{{template "base" .}}{{end}}
+
+{{define "index"}}{{template "base" .}}{{end}}
+`
@@ -0,0 +1,129 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/parser"
+	"github.com/yuin/goldmark/renderer"
+	"github.com/yuin/goldmark/text"
+	"github.com/yuin/goldmark/util"
+)
+
+// KindCallout is the ast.NodeKind for CalloutBlock.
+var KindCallout = ast.NewNodeKind("Callout")
+
+// CalloutBlock is a blockquote recognized as a GitHub-style callout, e.g.
+// `> [!NOTE]`. It replaces the blockquote in the tree and carries the
+// callout variant ("note", "tip", "important", "warning" or "caution").
+type CalloutBlock struct {
+	ast.BaseBlock
+	Variant string
+}
+
+func (n *CalloutBlock) Dump(source []byte, level int) {
+	ast.DumpHelper(n, source, level, map[string]string{"Variant": n.Variant}, nil)
+}
+
+func (n *CalloutBlock) Kind() ast.NodeKind { return KindCallout }
+
+// NewCalloutBlock returns an empty CalloutBlock for the given variant.
+func NewCalloutBlock(variant string) *CalloutBlock {
+	return &CalloutBlock{Variant: variant}
+}
+
+var calloutMarker = regexp.MustCompile(`^\[!(NOTE|TIP|IMPORTANT|WARNING|CAUTION)\]\s*$`)
+
+// calloutTransformer turns blockquotes whose first line is a GitHub-style
+// `[!NOTE]`-style marker into CalloutBlock nodes, mirroring how
+// Forgejo/Gitea layer the "callout" block on top of goldmark.
+type calloutTransformer struct{}
+
+func (t *calloutTransformer) Transform(doc *ast.Document, reader text.Reader, pc parser.Context) {
+	source := reader.Source()
+	var walk func(n ast.Node)
+	walk = func(n ast.Node) {
+		for c := n.FirstChild(); c != nil; {
+			next := c.NextSibling()
+			walk(c)
+			c = next
+		}
+		bq, ok := n.(*ast.Blockquote)
+		if !ok {
+			return
+		}
+		para, ok := bq.FirstChild().(*ast.Paragraph)
+		if !ok {
+			return
+		}
+		marker, ok := para.FirstChild().(*ast.Text)
+		if !ok {
+			return
+		}
+		m := calloutMarker.FindSubmatch(marker.Segment.Value(source))
+		if m == nil {
+			return
+		}
+		callout := NewCalloutBlock(strings.ToLower(string(m[1])))
+		if marker.NextSibling() == nil {
+			bq.RemoveChild(bq, para)
+		} else {
+			para.RemoveChild(para, marker)
+		}
+		for c := bq.FirstChild(); c != nil; {
+			next := c.NextSibling()
+			callout.AppendChild(callout, c)
+			c = next
+		}
+		bq.Parent().ReplaceChild(bq.Parent(), bq, callout)
+	}
+	walk(doc)
+}
+
+var calloutTitles = map[string]string{
+	"note": "Note", "tip": "Tip", "important": "Important",
+	"warning": "Warning", "caution": "Caution",
+}
+
+var calloutIcons = map[string]string{
+	"note": "ℹ️", "tip": "💡", "important": "❗", "warning": "⚠️", "caution": "🛑",
+}
+
+// calloutHTMLRenderer renders CalloutBlock as a <blockquote> with a
+// callout-specific class, a leading icon and a title.
+type calloutHTMLRenderer struct{}
+
+func (r *calloutHTMLRenderer) RegisterFuncs(reg renderer.NodeRendererFuncRegisterer) {
+	reg.Register(KindCallout, r.renderCallout)
+}
+
+func (r *calloutHTMLRenderer) renderCallout(w util.BufWriter, source []byte, n ast.Node, entering bool) (ast.WalkStatus, error) {
+	node := n.(*CalloutBlock)
+	if entering {
+		fmt.Fprintf(w, "<blockquote class=\"callout callout-%s\">\n", node.Variant)
+		fmt.Fprintf(w, "<p class=\"callout-title\"><span class=\"callout-icon\">%s</span> %s</p>\n",
+			calloutIcons[node.Variant], calloutTitles[node.Variant])
+	} else {
+		w.WriteString("</blockquote>\n")
+	}
+	return ast.WalkContinue, nil
+}
+
+// calloutExtension wires calloutTransformer and calloutHTMLRenderer into a
+// goldmark instance.
+type calloutExtension struct{}
+
+// Callout is the goldmark extension for GitHub-style callout blockquotes.
+var Callout = &calloutExtension{}
+
+func (e *calloutExtension) Extend(m goldmark.Markdown) {
+	m.Parser().AddOptions(parser.WithASTTransformers(
+		util.Prioritized(&calloutTransformer{}, 500),
+	))
+	m.Renderer().AddOptions(renderer.WithNodeRenderers(
+		util.Prioritized(&calloutHTMLRenderer{}, 500),
+	))
+}
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"crypto/hmac"
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/parser"
+	"github.com/yuin/goldmark/text"
+	"github.com/yuin/goldmark/util"
+)
+
+// camoConfig holds the Camo proxy endpoint and the HMAC-SHA1 key used to
+// sign rewritten URLs, matching the scheme Forgejo's modules/markup/camo.go
+// uses to proxy untrusted remote images.
+type camoConfig struct {
+	url string
+	key string
+}
+
+// rewrite returns the Camo-proxied form of raw: {camo-url}/{hex(hmac_sha1(key,
+// raw))}/{hex(raw)}. It leaves data: URIs and anything that isn't an
+// absolute http(s) URL (relative/page-relative image paths, which Camo
+// can't resolve anyway) untouched.
+func (c camoConfig) rewrite(raw string) string {
+	if raw == "" || strings.HasPrefix(raw, "data:") || !isAbsoluteHTTPURL(raw) {
+		return raw
+	}
+	mac := hmac.New(sha1.New, []byte(c.key))
+	mac.Write([]byte(raw))
+	signature := hex.EncodeToString(mac.Sum(nil))
+	encodedURL := hex.EncodeToString([]byte(raw))
+	return fmt.Sprintf("%s/%s/%s", strings.TrimRight(c.url, "/"), signature, encodedURL)
+}
+
+// isAbsoluteHTTPURL reports whether raw is an absolute http/https URL with
+// a host, i.e. something Camo can actually fetch and proxy.
+func isAbsoluteHTTPURL(raw string) bool {
+	u, err := url.Parse(raw)
+	if err != nil || u.Host == "" {
+		return false
+	}
+	return u.Scheme == "http" || u.Scheme == "https"
+}
+
+// imageURLPattern matches common image file extensions, used to decide
+// whether a plain <a href> link points at an image worth proxying too.
+var imageURLPattern = regexp.MustCompile(`(?i)\.(png|jpe?g|gif|webp|svg|bmp)(\?.*)?$`)
+
+// camoTransformer rewrites every image destination (and any link that
+// points directly at an image) in the document to a signed Camo URL.
+type camoTransformer struct {
+	cfg camoConfig
+}
+
+func (t *camoTransformer) Transform(doc *ast.Document, reader text.Reader, pc parser.Context) {
+	_ = ast.Walk(doc, func(n ast.Node, entering bool) (ast.WalkStatus, error) {
+		if !entering {
+			return ast.WalkContinue, nil
+		}
+		switch node := n.(type) {
+		case *ast.Image:
+			node.Destination = []byte(t.cfg.rewrite(string(node.Destination)))
+		case *ast.Link:
+			if imageURLPattern.MatchString(string(node.Destination)) {
+				node.Destination = []byte(t.cfg.rewrite(string(node.Destination)))
+			}
+		}
+		return ast.WalkContinue, nil
+	})
+}
+
+// camoExtension wires camoTransformer into a goldmark instance.
+type camoExtension struct {
+	cfg camoConfig
+}
+
+// newCamoExtension returns a goldmark extension that rewrites image URLs to
+// signed Camo URLs, for safely rendering Markdown from untrusted sources.
+func newCamoExtension(camoURL, camoKey string) goldmark.Extender {
+	return &camoExtension{cfg: camoConfig{url: camoURL, key: camoKey}}
+}
+
+func (e *camoExtension) Extend(m goldmark.Markdown) {
+	m.Parser().AddOptions(parser.WithASTTransformers(
+		util.Prioritized(&camoTransformer{cfg: e.cfg}, 600),
+	))
+}
@@ -0,0 +1,22 @@
+package main
+
+import (
+	"fmt"
+	"io"
+
+	chromahtml "github.com/alecthomas/chroma/formatters/html"
+	"github.com/alecthomas/chroma/styles"
+)
+
+// writeChromaCSS writes the CSS stylesheet for the named Chroma style to w.
+// It's the class-mode counterpart to -style: once inline code CSS is
+// turned off (chromahtml.WithClasses(true)), something has to emit the
+// matching stylesheet, and this is it.
+func writeChromaCSS(w io.Writer, styleName string) error {
+	style, ok := styles.Registry[styleName]
+	if !ok {
+		return fmt.Errorf("unknown chroma style %q, see https://xyproto.github.io/splash/docs/ for full list", styleName)
+	}
+	formatter := chromahtml.New(chromahtml.WithClasses(true))
+	return formatter.WriteCSS(w, style)
+}
@@ -18,6 +18,7 @@ import (
 	"github.com/yuin/goldmark/extension"
 	"github.com/yuin/goldmark/parser"
 	"github.com/yuin/goldmark/renderer/html"
+	"github.com/yuin/goldmark/text"
 )
 
 //go:embed default-style.css
@@ -33,6 +34,14 @@ func main() {
 	var page, showVersion bool
 	var css, cpuprofile string
 	var chromaStyle string
+	var toc, tocOnly bool
+	var tocDepth int
+	var format string
+	var mathRenderer string
+	var genChromaCSS string
+	var outputDir, templateDir string
+	var emitIndex bool
+	var camoURL, camoKey string
 
 	flag.BoolVar(&page, "page", true,
 		"Generate a standalone HTML page")
@@ -44,6 +53,28 @@ func main() {
 		"Chroma style, see https://xyproto.github.io/splash/docs/ for full list")
 	flag.StringVar(&cpuprofile, "cpuprofile", "",
 		"Write cpu profile to a file")
+	flag.BoolVar(&toc, "toc", false,
+		"Generate a table of contents and insert it at a [TOC] marker")
+	flag.BoolVar(&tocOnly, "toconly", false,
+		"Output only the table of contents, discarding the article body (implies -toc)")
+	flag.IntVar(&tocDepth, "toc-depth", 0,
+		"Limit the table of contents to headings at or above this level (0 means unlimited)")
+	flag.StringVar(&format, "format", "html",
+		"Output format: html, latex or json")
+	flag.StringVar(&mathRenderer, "math", "",
+		"Enable $...$/$$...$$ math spans, typeset client-side by the given renderer: mathjax or katex")
+	flag.StringVar(&genChromaCSS, "gen-chroma-css", "",
+		"Write the CSS stylesheet for the given Chroma style to stdout and exit (highlighting uses these CSS classes only when -style is not set; -style switches to inline styles)")
+	flag.StringVar(&outputDir, "out", "",
+		"Output directory (required when the input is a directory or glob)")
+	flag.StringVar(&templateDir, "template-dir", "",
+		"Directory of base.tmpl/article.tmpl/index.tmpl templates overriding the built-in page template (directory/glob mode)")
+	flag.BoolVar(&emitIndex, "index", false,
+		"Also emit an index.html listing all converted articles, sorted by date (directory/glob mode)")
+	flag.StringVar(&camoURL, "camo-url", "",
+		"Proxy remote images through this Camo instance (requires -camo-key)")
+	flag.StringVar(&camoKey, "camo-key", "",
+		"HMAC-SHA1 key shared with the Camo instance named by -camo-url")
 	flag.Usage = func() {
 		printVersion(os.Stderr)
 		fmt.Fprintf(os.Stderr,
@@ -60,11 +91,22 @@ func main() {
 		return
 	}
 
+	if genChromaCSS != "" {
+		if err := writeChromaCSS(os.Stdout, genChromaCSS); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(-1)
+		}
+		return
+	}
+
 	// enforce implied options
 	if css != "" {
 		page = true
 		css = fmt.Sprintf(`<link crossorigin="anonymous" media="all" rel="stylesheet" href="%s" />`, css)
 	}
+	if tocOnly {
+		toc = true
+	}
 
 	// turn on profiling?
 	if cpuprofile != "" {
@@ -76,11 +118,64 @@ func main() {
 		defer pprof.StopCPUProfile()
 	}
 
+	var mathScripts template.HTML
+	extensions := []goldmark.Extender{extension.GFM, extension.Typographer, Callout}
+	if camoURL != "" {
+		extensions = append(extensions, newCamoExtension(camoURL, camoKey))
+	}
+	if mathRenderer != "" {
+		mathExt, err := mathExtension(mathRenderer)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(-1)
+		}
+		extensions = append(extensions, mathExt)
+		mathScripts = mathHeaderScripts(mathRenderer)
+	}
+
+	// parse and render
+	inlineCodeCss := chromaStyle != ""
+	extensions = append(extensions, highlighting.NewHighlighting(
+		highlighting.WithStyle(chromaStyle),
+		highlighting.WithFormatOptions(
+			chromahtml.WithLineNumbers(false),
+			chromahtml.LineNumbersInTable(true),
+			chromahtml.TabWidth(4),
+			chromahtml.WithClasses(!inlineCodeCss),
+		),
+	))
+	markdown := goldmark.New(
+		goldmark.WithExtensions(extensions...),
+		goldmark.WithParserOptions(
+			parser.WithAutoHeadingID(),
+		),
+		goldmark.WithRendererOptions(
+			html.WithHardWraps(),
+			html.WithXHTML(),
+		),
+	)
+
+	// the non-flag command-line arguments
+	args := flag.Args()
+
+	if len(args) == 1 && isBatchInput(args[0]) {
+		opts := batchOptions{
+			format:      format,
+			toc:         toc,
+			tocOnly:     tocOnly,
+			tocDepth:    tocDepth,
+			mathScripts: mathScripts,
+		}
+		if err := runBatch(markdown, args[0], outputDir, templateDir, opts, emitIndex); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(-1)
+		}
+		return
+	}
+
 	// read the input
 	var input []byte
 	var err error
-	// the non-flag command-line arguments
-	args := flag.Args()
 
 	switch len(args) {
 	case 0:
@@ -103,35 +198,36 @@ func main() {
 		title = getTitle(input)
 	}
 
-	// parse and render
-	inlineCodeCss := chromaStyle != ""
-	markdown := goldmark.New(
-		goldmark.WithExtensions(extension.GFM),
-		goldmark.WithParserOptions(
-			parser.WithAutoHeadingID(),
-		),
-		goldmark.WithRendererOptions(
-			html.WithHardWraps(),
-			html.WithXHTML(),
-		),
-		goldmark.WithExtensions(
-			highlighting.NewHighlighting(
-				highlighting.WithStyle(chromaStyle),
-				highlighting.WithFormatOptions(
-					chromahtml.WithLineNumbers(false),
-					chromahtml.LineNumbersInTable(true),
-					chromahtml.TabWidth(4),
-					chromahtml.WithClasses(!inlineCodeCss),
-				),
-			),
-		),
-		goldmark.WithExtensions(extension.Typographer),
-	)
+	reader := text.NewReader(input)
+	doc := markdown.Parser().Parse(reader)
+
+	var tocHTML string
+	if toc && format == "html" {
+		tocHTML = renderTOC(collectTOC(doc, input, tocDepth))
+	}
+
+	renderer, err := newRenderer(format, markdown)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(-1)
+	}
+
 	var output bytes.Buffer
-	if err := markdown.Convert(input, &output); err != nil {
+	if err := renderer.Render(&output, input, doc); err != nil {
 		panic(err)
 	}
 
+	if format == "html" {
+		if tocOnly {
+			output.Reset()
+			output.WriteString(tocHTML)
+		} else if toc {
+			replaced := strings.Replace(output.String(), "<p>[TOC]</p>\n", tocHTML, 1)
+			output.Reset()
+			output.WriteString(replaced)
+		}
+	}
+
 	// output the result
 	var out *os.File
 	if len(args) == 2 {
@@ -144,7 +240,7 @@ func main() {
 		out = os.Stdout
 	}
 
-	if page {
+	if page && format == "html" {
 		tmpl, err := template.New("header").Parse(header)
 		if err != nil {
 			fmt.Fprintln(os.Stderr, "Error parse template:", err)
@@ -153,17 +249,22 @@ func main() {
 			Title        string
 			DefaultStyle template.CSS
 			StyleLink    template.HTML
-		}{Title: title, DefaultStyle: template.CSS(defaultStyle), StyleLink: template.HTML(css)}); err != nil {
+			MathScripts  template.HTML
+		}{Title: title, DefaultStyle: template.CSS(defaultStyle), StyleLink: template.HTML(css), MathScripts: mathScripts}); err != nil {
 			fmt.Fprintln(os.Stderr, "Error execute template:", err)
 		}
 	}
-	out.WriteString(`<article class="markdown-body">`)
+	if format == "html" {
+		out.WriteString(`<article class="markdown-body">`)
+	}
 	if _, err = out.Write(output.Bytes()); err != nil {
 		fmt.Fprintln(os.Stderr, "Error writing output:", err)
 		os.Exit(-1)
 	}
-	out.WriteString(`</article>`)
-	if page {
+	if format == "html" {
+		out.WriteString(`</article>`)
+	}
+	if page && format == "html" {
 		out.WriteString(footer)
 	}
 }
@@ -236,6 +337,8 @@ const header = `
 
 	{{.StyleLink}}
 
+	{{.MathScripts}}
+
 	</head>
 	<body>
 `
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"fmt"
+	"html/template"
+
+	mathjax "github.com/litao91/goldmark-mathjax"
+	"github.com/yuin/goldmark"
+)
+
+// mathExtension resolves the -math flag to the goldmark extension that
+// recognizes $...$/$$...$$ spans and renders them as \(...\)/\[...\] (see
+// the mathJaxScripts comment below), ready for client-side typesetting.
+// Note this is goldmark-mathjax's own output, not the <span class="math">/
+// <div class="math"> markup some other Markdown math extensions use; don't
+// expect those classes to be present if you're styling the output. The
+// renderer choice only changes which scripts mathHeaderScripts injects;
+// the generated markup is the same either way.
+func mathExtension(renderer string) (goldmark.Extender, error) {
+	switch renderer {
+	case "mathjax", "katex":
+		return mathjax.MathJax, nil
+	default:
+		return nil, fmt.Errorf("unknown -math renderer %q (want mathjax or katex)", renderer)
+	}
+}
+
+// mathHeaderScripts returns the <script>/<link> tags that load the chosen
+// client-side renderer, for injection into the standalone page header.
+func mathHeaderScripts(renderer string) template.HTML {
+	switch renderer {
+	case "mathjax":
+		return template.HTML(mathJaxScripts)
+	case "katex":
+		return template.HTML(katexScripts)
+	default:
+		return ""
+	}
+}
+
+// The delimiters below must match what goldmark-mathjax actually emits,
+// not the $...$/$$...$$ Markdown syntax it consumes: it renders spans as
+// \(...\) and \[...\] in the HTML output.
+//
+// The integrity hashes below are SRI digests for the exact pinned
+// versions (mathjax@3's tex-mml-chtml.js, katex@0.16.9's assets) and were
+// taken from jsdelivr's published values at the time these were added.
+// They are not re-verified by any build step: a browser checks them at
+// load time and, if a hash doesn't match the fetched file, refuses to run
+// the script without raising anything louder than a console warning, so
+// math silently stops rendering. If you bump either pinned version,
+// regenerate the matching hash (e.g. `curl -s <url> | openssl dgst
+// -sha384 -binary | openssl base64 -A`) rather than editing the version
+// number alone.
+const mathJaxScripts = `<script>
+  window.MathJax = { tex: { inlineMath: [['\\(', '\\)']], displayMath: [['\\[', '\\]']] } };
+</script>
+<script id="MathJax-script" async
+  src="https://cdn.jsdelivr.net/npm/mathjax@3/es5/tex-mml-chtml.js"
+  integrity="sha384-HH4sr8TmXn9JPjx0b1R5mBVOiAC9DjFJVj5s4xW//oJ8rkwI2/9fXKJpavbl6U2I"
+  crossorigin="anonymous"></script>`
+
+const katexScripts = `<link rel="stylesheet"
+  href="https://cdn.jsdelivr.net/npm/katex@0.16.9/dist/katex.min.css"
+  integrity="sha384-n8MVd4RsNIU0tAv4ct0nTaAbDJwPJzDEaqSD1odI+WdtXRGWt2kTvGFasHpSy3SV"
+  crossorigin="anonymous">
+<script defer src="https://cdn.jsdelivr.net/npm/katex@0.16.9/dist/katex.min.js"
+  integrity="sha384-XjKyOOlGwcjNTAIQHIpgOno0Hl1YQqzUOEleOLALmuqehneUG+vnGctmUb0ZY0l8"
+  crossorigin="anonymous"></script>
+<script defer src="https://cdn.jsdelivr.net/npm/katex@0.16.9/dist/contrib/auto-render.min.js"
+  integrity="sha384-+VBxd3r6XgURycqtZ117nYw44OOcIax56Z4dCRWbxyPt0Koah1uHoK0o4+/RRE05"
+  crossorigin="anonymous"
+  onload="renderMathInElement(document.body, {delimiters: [
+    {left: '\\[', right: '\\]', display: true},
+    {left: '\\(', right: '\\)', display: false}
+  ]});"></script>`
@@ -0,0 +1,198 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"html"
+	"io"
+	"strings"
+
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/ast"
+)
+
+// Renderer turns a parsed goldmark document into a specific output format.
+// It lets -format swap the HTML renderer for alternatives without main
+// having to know about their internals.
+type Renderer interface {
+	Render(w io.Writer, source []byte, doc ast.Node) error
+}
+
+// newRenderer resolves the -format flag value to a Renderer, reusing the
+// already-configured goldmark instance for the "html" case.
+func newRenderer(format string, markdown goldmark.Markdown) (Renderer, error) {
+	switch format {
+	case "", "html":
+		return htmlRenderer{markdown}, nil
+	case "latex":
+		return latexRenderer{}, nil
+	case "json":
+		return jsonRenderer{}, nil
+	default:
+		return nil, fmt.Errorf("unknown -format %q (want html, latex or json)", format)
+	}
+}
+
+// htmlRenderer defers to goldmark's own renderer, preserving today's
+// behavior when -format is html or unset.
+type htmlRenderer struct {
+	markdown goldmark.Markdown
+}
+
+func (r htmlRenderer) Render(w io.Writer, source []byte, doc ast.Node) error {
+	return r.markdown.Renderer().Render(w, source, doc)
+}
+
+// latexRenderer maps a small, common subset of the goldmark AST to LaTeX
+// commands. It is meant for simple documents (headings, paragraphs,
+// emphasis, lists, code blocks and links); anything more exotic is
+// passed through as plain text. Raw HTML and thematic breaks produce no
+// output.
+type latexRenderer struct{}
+
+func (latexRenderer) Render(w io.Writer, source []byte, doc ast.Node) error {
+	var buf bytes.Buffer
+	if err := renderLatexChildren(&buf, source, doc); err != nil {
+		return err
+	}
+	_, err := w.Write(buf.Bytes())
+	return err
+}
+
+func renderLatexChildren(buf *bytes.Buffer, source []byte, n ast.Node) error {
+	for c := n.FirstChild(); c != nil; c = c.NextSibling() {
+		if err := renderLatexNode(buf, source, c); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// latexEscaper escapes the characters LaTeX treats specially, so that
+// ordinary prose (percentages, underscores, dollar signs, ampersands...)
+// doesn't break or get silently dropped by the output.
+var latexEscaper = strings.NewReplacer(
+	`\`, `\textbackslash{}`,
+	`{`, `\{`,
+	`}`, `\}`,
+	`$`, `\$`,
+	`&`, `\&`,
+	`#`, `\#`,
+	`_`, `\_`,
+	`%`, `\%`,
+	`~`, `\textasciitilde{}`,
+	`^`, `\textasciicircum{}`,
+)
+
+func latexEscape(s string) string {
+	return latexEscaper.Replace(s)
+}
+
+var latexSectioning = [...]string{
+	1: `\section`, 2: `\subsection`, 3: `\subsubsection`,
+	4: `\paragraph`, 5: `\subparagraph`, 6: `\subparagraph`,
+}
+
+func renderLatexNode(buf *bytes.Buffer, source []byte, n ast.Node) error {
+	switch node := n.(type) {
+	case *ast.Heading:
+		fmt.Fprintf(buf, "%s{", latexSectioning[node.Level])
+		if err := renderLatexChildren(buf, source, n); err != nil {
+			return err
+		}
+		buf.WriteString("}\n\n")
+	case *ast.Paragraph:
+		if err := renderLatexChildren(buf, source, n); err != nil {
+			return err
+		}
+		buf.WriteString("\n\n")
+	case *ast.Emphasis:
+		tag := `\emph`
+		if node.Level >= 2 {
+			tag = `\textbf`
+		}
+		buf.WriteString(tag + "{")
+		if err := renderLatexChildren(buf, source, n); err != nil {
+			return err
+		}
+		buf.WriteString("}")
+	case *ast.FencedCodeBlock, *ast.CodeBlock:
+		buf.WriteString("\\begin{verbatim}\n")
+		lines := n.Lines()
+		for i := 0; i < lines.Len(); i++ {
+			seg := lines.At(i)
+			buf.Write(seg.Value(source))
+		}
+		buf.WriteString("\\end{verbatim}\n\n")
+	case *ast.CodeSpan:
+		buf.WriteString(`\texttt{`)
+		buf.WriteString(latexEscape(string(node.Text(source))))
+		buf.WriteString("}")
+	case *ast.List:
+		env := "itemize"
+		if node.IsOrdered() {
+			env = "enumerate"
+		}
+		fmt.Fprintf(buf, "\\begin{%s}\n", env)
+		if err := renderLatexChildren(buf, source, n); err != nil {
+			return err
+		}
+		fmt.Fprintf(buf, "\\end{%s}\n\n", env)
+	case *ast.ListItem:
+		buf.WriteString(`\item `)
+		if err := renderLatexChildren(buf, source, n); err != nil {
+			return err
+		}
+		buf.WriteString("\n")
+	case *ast.Link:
+		fmt.Fprintf(buf, `\href{%s}{`, latexEscape(string(node.Destination)))
+		if err := renderLatexChildren(buf, source, n); err != nil {
+			return err
+		}
+		buf.WriteString("}")
+	case *ast.Text:
+		buf.WriteString(latexEscape(string(node.Segment.Value(source))))
+		if node.SoftLineBreak() || node.HardLineBreak() {
+			buf.WriteString("\n")
+		}
+	case *ast.String:
+		buf.WriteString(latexEscape(html.UnescapeString(string(node.Value))))
+	case *ast.AutoLink:
+		buf.WriteString(latexEscape(string(node.Label(source))))
+	default:
+		return renderLatexChildren(buf, source, n)
+	}
+	return nil
+}
+
+// jsonRenderer dumps the parsed AST as JSON, letting downstream tooling
+// consume the document structure without re-parsing Markdown.
+type jsonRenderer struct{}
+
+// jsonNode is the serializable mirror of an ast.Node.
+type jsonNode struct {
+	Type     string     `json:"type"`
+	Text     string     `json:"text,omitempty"`
+	Children []jsonNode `json:"children,omitempty"`
+}
+
+func (jsonRenderer) Render(w io.Writer, source []byte, doc ast.Node) error {
+	root := toJSONNode(source, doc)
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(root)
+}
+
+func toJSONNode(source []byte, n ast.Node) jsonNode {
+	jn := jsonNode{Type: n.Kind().String()}
+	if n.Type() == ast.TypeInline || n.Kind() == ast.KindText {
+		if t, ok := n.(*ast.Text); ok {
+			jn.Text = string(t.Segment.Value(source))
+		}
+	}
+	for c := n.FirstChild(); c != nil; c = c.NextSibling() {
+		jn.Children = append(jn.Children, toJSONNode(source, c))
+	}
+	return jn
+}
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"html"
+
+	"github.com/yuin/goldmark/ast"
+)
+
+// tocEntry is a single heading collected while walking the document tree.
+type tocEntry struct {
+	level int
+	id    string
+	text  string
+}
+
+// collectTOC walks doc and returns every heading up to maxDepth, in
+// document order. maxDepth <= 0 means no limit.
+func collectTOC(doc ast.Node, source []byte, maxDepth int) []tocEntry {
+	var entries []tocEntry
+	_ = ast.Walk(doc, func(n ast.Node, entering bool) (ast.WalkStatus, error) {
+		if !entering {
+			return ast.WalkContinue, nil
+		}
+		heading, ok := n.(*ast.Heading)
+		if !ok {
+			return ast.WalkContinue, nil
+		}
+		if maxDepth > 0 && heading.Level > maxDepth {
+			return ast.WalkContinue, nil
+		}
+		var id string
+		if raw, ok := heading.AttributeString("id"); ok {
+			if s, ok := raw.([]byte); ok {
+				id = string(s)
+			} else if s, ok := raw.(string); ok {
+				id = s
+			}
+		}
+		entries = append(entries, tocEntry{
+			level: heading.Level,
+			id:    id,
+			text:  string(heading.Text(source)),
+		})
+		return ast.WalkContinue, nil
+	})
+	return entries
+}
+
+// renderTOC renders entries as a nested <ul> of anchor links, opening and
+// closing sub-lists as the heading level rises and falls.
+func renderTOC(entries []tocEntry) string {
+	if len(entries) == 0 {
+		return ""
+	}
+	var buf bytes.Buffer
+	buf.WriteString(`<nav class="toc">` + "\n")
+	baseLevel := entries[0].level
+	level := baseLevel
+	buf.WriteString("<ul>\n")
+	openLi := false
+	for _, e := range entries {
+		switch {
+		case e.level > level:
+			// Nest the new sublist inside the still-open parent <li>.
+			for e.level > level {
+				buf.WriteString("<ul>\n")
+				level++
+			}
+		case e.level < level:
+			for e.level < level && level > baseLevel {
+				buf.WriteString("</li>\n</ul>\n")
+				level--
+			}
+			buf.WriteString("</li>\n")
+		default:
+			if openLi {
+				buf.WriteString("</li>\n")
+			}
+		}
+		fmt.Fprintf(&buf, "<li><a href=\"#%s\">%s</a>", e.id, html.EscapeString(e.text))
+		openLi = true
+	}
+	if openLi {
+		buf.WriteString("</li>\n")
+	}
+	for level > baseLevel {
+		buf.WriteString("</ul>\n")
+		level--
+	}
+	buf.WriteString("</ul>\n</nav>\n")
+	return buf.String()
+}